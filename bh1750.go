@@ -3,6 +3,7 @@ package bh1750
 import (
 	"encoding/binary"
 	"errors"
+	"math"
 	"time"
 
 	i2c "github.com/d2r2/go-i2c"
@@ -55,6 +56,39 @@ const (
 	CMD_CHANGE_MEAS_TIME_LOW = 0x60
 )
 
+// Sensitivity factor limits, as specified in the datasheet.
+const (
+	// MinSensivityFactor is the lowest factor accepted by ChangeSensivityFactor.
+	MinSensivityFactor = 31
+	// MaxSensivityFactor is the highest factor accepted by ChangeSensivityFactor.
+	MaxSensivityFactor = 254
+)
+
+// Accuracy factor limits, as specified in the datasheet. Accuracy
+// compensates for deviation introduced by a colored cover/window, or
+// for chip-to-chip variation found during calibration.
+const (
+	// MinAccuracyFactor is the lowest accuracy accepted by SetAccuracyFactor.
+	MinAccuracyFactor = 0.96
+	// MaxAccuracyFactor is the highest accuracy accepted by SetAccuracyFactor.
+	MaxAccuracyFactor = 1.44
+	// DefaultAccuracyFactor is the accuracy used when the sensor has
+	// no cover and hasn't been individually calibrated.
+	DefaultAccuracyFactor = 1.2
+)
+
+// Default raw count thresholds used by MeasureAmbientLightAuto
+// to detect saturation (too much light) and near-zero counts
+// (too little light), mirroring the auto-scaling approach
+// found in other BH1750 drivers (e.g. ESPHome's).
+const (
+	// defaultAutoSaturationThreshold is ~90% of the 16-bit raw
+	// counter ceiling (0xFFFF).
+	defaultAutoSaturationThreshold = 0xFFFF * 9 / 10
+	// defaultAutoLowCountThreshold flags a measurement as "dim".
+	defaultAutoLowCountThreshold = 1000
+)
+
 // ResolutionMode define sensor sensitivity
 // and measure time. Be aware, that improving
 // sensitivity lead to increasing of measurement time.
@@ -83,6 +117,15 @@ func (v ResolutionMode) String() string {
 	}
 }
 
+// I2C addresses the sensor answers on, depending on how the ADDR
+// pin is wired: tie it low (or leave it floating) for Address0x23,
+// tie it high (Vcc) for Address0x5C. This lets two sensors share a
+// single i2c bus, see NewBH1750Pair.
+const (
+	Address0x23 = 0x23
+	Address0x5C = 0x5C
+)
+
 // BH1750 it's a sensor itself.
 type BH1750 struct {
 	// Since sensor have no register
@@ -91,15 +134,104 @@ type BH1750 struct {
 	lastCmd        byte
 	lastResolution ResolutionMode
 	factor         byte
+	accuracy       float64
+
+	// I2C address this instance was created for, see
+	// NewBH1750AtAddress. Informational only — the caller is
+	// responsible for opening the matching i2c.I2C connection.
+	address byte
+
+	// Resolution and factor selected by the last
+	// MeasureAmbientLightAuto call.
+	lastAutoResolution ResolutionMode
+	lastAutoFactor     byte
+
+	// Raw count thresholds used by MeasureAmbientLightAuto
+	// to decide when to change resolution/factor.
+	autoSaturationThreshold uint16
+	autoLowCountThreshold   uint16
 }
 
-// NewBH1750 return new sensor instance.
+// NewBH1750 return new sensor instance for the default address (0x23).
 func NewBH1750() *BH1750 {
-	v := &BH1750{}
-	v.factor = v.GetDefaultSensivityFactor()
+	v, err := NewBH1750AtAddress(Address0x23)
+	if err != nil {
+		// Address0x23 is always valid, this can't happen.
+		panic(err)
+	}
 	return v
 }
 
+// NewBH1750AtAddress return new sensor instance for addr, which must
+// be either Address0x23 or Address0x5C. The caller is still
+// responsible for opening an i2c.I2C connection at that address (via
+// i2c.NewI2C) and passing it to the measurement methods.
+func NewBH1750AtAddress(addr byte) (*BH1750, error) {
+	if addr != Address0x23 && addr != Address0x5C {
+		return nil, errors.New(spew.Sprintf(
+			"unsupported sensor address 0x%X, must be 0x%X or 0x%X",
+			addr, Address0x23, Address0x5C))
+	}
+
+	v := &BH1750{address: addr}
+	v.factor = v.GetDefaultSensivityFactor()
+	v.accuracy = DefaultAccuracyFactor
+	v.autoSaturationThreshold = defaultAutoSaturationThreshold
+	v.autoLowCountThreshold = defaultAutoLowCountThreshold
+	return v, nil
+}
+
+// NewBH1750Pair create two sensor instances wired to share one bus
+// via the ADDR pin (Address0x23 and Address0x5C respectively) and
+// Probe both over the i2c connections bus0 and bus1, so callers can
+// run e.g. an indoor and an outdoor sensor side by side.
+func NewBH1750Pair(bus0, bus1 *i2c.I2C) (*BH1750, *BH1750, error) {
+	v0, err := NewBH1750AtAddress(Address0x23)
+	if err != nil {
+		return nil, nil, err
+	}
+	v1, err := NewBH1750AtAddress(Address0x5C)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := v0.Probe(bus0); err != nil {
+		return nil, nil, err
+	}
+	if err := v1.Probe(bus1); err != nil {
+		return nil, nil, err
+	}
+
+	return v0, v1, nil
+}
+
+// Probe checks that a sensor actually acknowledges on the bus, by
+// issuing CMD_POWER_ON, without waiting out a full measurement cycle.
+// Use this to tell a wired BH1750 apart from an absent or
+// mis-addressed device while scanning a bus.
+func (v *BH1750) Probe(i2c *i2c.I2C) error {
+	lg.Debug("Probe sensor...")
+	return v.PowerOn(i2c)
+}
+
+// SetAccuracyFactor override the accuracy value used to convert raw
+// counts into lux. Use this once you have calibrated your specific
+// chip, or to compensate for a colored protection cover/window.
+func (v *BH1750) SetAccuracyFactor(accuracy float64) error {
+	if accuracy < MinAccuracyFactor || accuracy > MaxAccuracyFactor {
+		return errors.New(spew.Sprintf("accuracy factor value exceed range [%v..%v]",
+			MinAccuracyFactor, MaxAccuracyFactor))
+	}
+	v.accuracy = accuracy
+	return nil
+}
+
+// GetAccuracyFactor return the accuracy value currently used to
+// convert raw counts into lux.
+func (v *BH1750) GetAccuracyFactor() float64 {
+	return v.accuracy
+}
+
 // Reset clear ambient light register value.
 func (v *BH1750) Reset(i2c *i2c.I2C) error {
 	lg.Debug("Reset sensor...")
@@ -163,36 +295,216 @@ func (v *BH1750) getResolutionData(resolution ResolutionMode) (cmd byte,
 	return cmd, wait, divider
 }
 
-// MeasureAmbientLightOneTime measure and return ambient light once in lux.
-func (v *BH1750) MeasureAmbientLightOneTime(i2c *i2c.I2C,
-	resolution ResolutionMode) (uint16, error) {
+// readAmbientLightRaw reads the raw (uncalibrated) 16 bit counter
+// value off the data register.
+func readAmbientLightRaw(i2c *i2c.I2C) (uint16, error) {
+	var data struct {
+		Data [2]byte
+	}
+	err := readDataToStruct(i2c, 2, binary.BigEndian, &data)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(data.Data[0])<<8 | uint16(data.Data[1]), nil
+}
 
-	lg.Debug("Run one time measure...")
+// measureOneTime issues a one-time measurement command for resolution,
+// waits the recommended amount of time and returns the raw counter
+// value together with the divider that applies to it.
+func (v *BH1750) measureOneTime(i2c *i2c.I2C,
+	resolution ResolutionMode) (raw uint16, divider uint32, err error) {
 
 	cmd, wait, divider := v.getResolutionData(resolution)
 
 	v.lastCmd = cmd
 	v.lastResolution = resolution
 
-	_, err := i2c.WriteBytes([]byte{cmd})
+	_, err = i2c.WriteBytes([]byte{cmd})
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	time.Sleep(wait)
 
-	var data struct {
-		Data [2]byte
+	raw, err = readAmbientLightRaw(i2c)
+	if err != nil {
+		return 0, 0, err
 	}
-	err = readDataToStruct(i2c, 2, binary.BigEndian, &data)
+
+	return raw, divider, nil
+}
+
+// computeLux convert a raw counter value and its divider into lux,
+// applying both the accuracy calibration and the sensitivity factor
+// correction: lux = raw / accuracy / divider * (defaultFactor /
+// factor). This is the single lux-conversion formula used by every
+// measurement method in the package (one-time, continuous and auto),
+// so a given raw/accuracy/factor combination always maps to the same
+// lux value no matter which method produced it.
+func (v *BH1750) computeLux(raw uint16, divider uint32) float64 {
+	return float64(raw) / v.accuracy / float64(divider) *
+		(float64(v.GetDefaultSensivityFactor()) / float64(v.factor))
+}
+
+// MeasureAmbientLightFloat measure and return ambient light once in
+// lux, keeping the fractional part that HighestResolution mode (0.5
+// lx counts) can produce.
+func (v *BH1750) MeasureAmbientLightFloat(i2c *i2c.I2C,
+	resolution ResolutionMode) (float64, error) {
+
+	lg.Debug("Run one time measure (float)...")
+
+	raw, divider, err := v.measureOneTime(i2c, resolution)
 	if err != nil {
 		return 0, err
 	}
 
-	amb := uint16(uint32(uint16(data.Data[0])<<8|uint16(data.Data[1])) *
-		5 / 6 / divider)
+	return v.computeLux(raw, divider), nil
+}
 
-	return amb, nil
+// roundLuxToUint16 round lux to the nearest integer and clamp it to
+// the uint16 range. The factor correction in computeLux means lux
+// can legitimately exceed 65535 (e.g. a low factor with a bright
+// scene), and converting an out-of-range float64 to uint16 directly
+// is implementation-defined, so clamp rather than cast blindly.
+func roundLuxToUint16(lux float64) uint16 {
+	rounded := math.Round(lux)
+	if rounded <= 0 {
+		return 0
+	}
+	if rounded >= math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(rounded)
+}
+
+// MeasureAmbientLightOneTime measure and return ambient light once in
+// lux, rounded to the nearest integer. See MeasureAmbientLightFloat
+// for the unrounded value.
+func (v *BH1750) MeasureAmbientLightOneTime(i2c *i2c.I2C,
+	resolution ResolutionMode) (uint16, error) {
+
+	lux, err := v.MeasureAmbientLightFloat(i2c, resolution)
+	if err != nil {
+		return 0, err
+	}
+
+	return roundLuxToUint16(lux), nil
+}
+
+// MeasureAmbientLightAuto picks the resolution and sensitivity
+// factor that best fit current lighting conditions, instead of
+// forcing the caller to choose one upfront. It starts with a probe
+// read at HighResolution and the default factor; if the raw counter
+// saturates, it falls back to LowResolution and, if still saturated,
+// lowers the factor toward MinSensivityFactor to shorten integration
+// time and widen the dynamic range. If the raw counter comes back
+// very low instead, it switches to HighestResolution and raises the
+// factor toward MaxSensivityFactor to improve resolution in dim
+// conditions. This mirrors the auto-scaling approach used by other
+// BH1750 drivers (e.g. ESPHome's). The picked raw counter is turned
+// into lux via computeLux.
+func (v *BH1750) MeasureAmbientLightAuto(i2c *i2c.I2C) (lux float64, err error) {
+
+	lg.Debug("Run auto-ranging measure...")
+
+	resolution := HighResolution
+	factor := v.GetDefaultSensivityFactor()
+	if err = v.ChangeSensivityFactor(i2c, factor); err != nil {
+		return 0, err
+	}
+
+	raw, divider, err := v.measureOneTime(i2c, resolution)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		nextResolution, nextFactor, changed := nextAutoRangeStep(raw, resolution, factor,
+			v.GetDefaultSensivityFactor(), v.autoSaturationThreshold, v.autoLowCountThreshold)
+		if !changed {
+			break
+		}
+
+		resolution = nextResolution
+		if nextFactor != factor {
+			factor = nextFactor
+			if err = v.ChangeSensivityFactor(i2c, factor); err != nil {
+				return 0, err
+			}
+		}
+
+		raw, divider, err = v.measureOneTime(i2c, resolution)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	v.lastAutoResolution = resolution
+	v.lastAutoFactor = factor
+
+	return v.computeLux(raw, divider), nil
+}
+
+// nextAutoRangeStep is the pure decision logic behind
+// MeasureAmbientLightAuto: given the raw counter value just read at
+// resolution/factor, it decides whether to switch to a different
+// resolution/factor and measure again (changed == true) or whether
+// the current raw reading is good enough to convert to lux as-is
+// (changed == false). It has no hardware dependency, so it can be
+// exercised with a table-driven test instead of only on real silicon.
+//
+// The state machine mirrors MeasureAmbientLightAuto's original
+// nested-if shape and is identified purely by (resolution, factor):
+//   - probe (HighResolution, defaultFactor): fall back to
+//     LowResolution on saturation, or jump to (HighestResolution,
+//     MaxSensivityFactor) on a very low count;
+//   - post-fallback (LowResolution, defaultFactor): floor the factor
+//     to MinSensivityFactor if still saturated;
+//   - any other (resolution, factor) combination is terminal — the
+//     algorithm never revisits a decision it already made.
+func nextAutoRangeStep(raw uint16, resolution ResolutionMode, factor byte,
+	defaultFactor byte, saturationThreshold, lowCountThreshold uint16) (
+	nextResolution ResolutionMode, nextFactor byte, changed bool) {
+
+	switch {
+	case resolution == HighResolution && factor == defaultFactor:
+		switch {
+		case raw >= saturationThreshold:
+			return LowResolution, factor, true
+		case raw < lowCountThreshold:
+			return HighestResolution, MaxSensivityFactor, true
+		default:
+			return resolution, factor, false
+		}
+	case resolution == LowResolution && factor == defaultFactor:
+		if raw >= saturationThreshold && factor > MinSensivityFactor {
+			return resolution, MinSensivityFactor, true
+		}
+		return resolution, factor, false
+	default:
+		return resolution, factor, false
+	}
+}
+
+// GetLastAutoResolution return the resolution mode selected by the
+// most recent MeasureAmbientLightAuto call.
+func (v *BH1750) GetLastAutoResolution() ResolutionMode {
+	return v.lastAutoResolution
+}
+
+// GetLastAutoSensivityFactor return the sensitivity factor selected
+// by the most recent MeasureAmbientLightAuto call.
+func (v *BH1750) GetLastAutoSensivityFactor() byte {
+	return v.lastAutoFactor
+}
+
+// SetAutoRangeThresholds override the raw counter thresholds used by
+// MeasureAmbientLightAuto to detect saturation (high) and near-zero
+// readings (low). Defaults are ~90% of 0xFFFF and 1000 respectively.
+func (v *BH1750) SetAutoRangeThresholds(low, high uint16) {
+	v.autoLowCountThreshold = low
+	v.autoSaturationThreshold = high
 }
 
 // StartMeasureAmbientLightContinuously start continuous
@@ -228,12 +540,12 @@ func (v *BH1750) StartMeasureAmbientLightContinuously(i2c *i2c.I2C,
 	return wait, nil
 }
 
-// FetchMeasuredAmbientLight return current average ambient light in lux.
-// Previous command should be any continuous measurement initiation,
-// otherwise error will be reported.
-func (v *BH1750) FetchMeasuredAmbientLight(i2c *i2c.I2C) (uint16, error) {
+// FetchMeasuredAmbientLightFloat return current average ambient light
+// in lux, keeping the fractional part. Previous command should be any
+// continuous measurement initiation, otherwise error will be reported.
+func (v *BH1750) FetchMeasuredAmbientLightFloat(i2c *i2c.I2C) (float64, error) {
 
-	lg.Debug("Fetch measured data...")
+	lg.Debug("Fetch measured data (float)...")
 
 	cmd, _, divider := v.getResolutionData(v.lastResolution)
 
@@ -242,18 +554,24 @@ func (v *BH1750) FetchMeasuredAmbientLight(i2c *i2c.I2C) (uint16, error) {
 			"can't fetch measured ambient light, since last command doesn't match")
 	}
 
-	var data struct {
-		Data [2]byte
-	}
-	err := readDataToStruct(i2c, 2, binary.BigEndian, &data)
+	raw, err := readAmbientLightRaw(i2c)
 	if err != nil {
 		return 0, err
 	}
 
-	amb := uint16(uint32(uint16(data.Data[0])<<8|uint16(data.Data[1])) *
-		5 / 6 / divider)
+	return v.computeLux(raw, divider), nil
+}
 
-	return amb, nil
+// FetchMeasuredAmbientLight return current average ambient light in
+// lux, rounded to the nearest integer. See
+// FetchMeasuredAmbientLightFloat for the unrounded value.
+func (v *BH1750) FetchMeasuredAmbientLight(i2c *i2c.I2C) (uint16, error) {
+	lux, err := v.FetchMeasuredAmbientLightFloat(i2c)
+	if err != nil {
+		return 0, err
+	}
+
+	return roundLuxToUint16(lux), nil
 }
 
 // GetDefaultSensivityFactor return factor value
@@ -274,14 +592,9 @@ func (v *BH1750) ChangeSensivityFactor(i2c *i2c.I2C, factor byte) error {
 
 	lg.Debug("Change sensitivity factor...")
 
-	// minimum limit
-	const minValue = 31
-	// maximum limit
-	const maxValue = 254
-
-	if factor < minValue || factor > maxValue {
+	if factor < MinSensivityFactor || factor > MaxSensivityFactor {
 		return errors.New(spew.Sprintf("sensitivity factor value exceed range [%d..%d]",
-			minValue, maxValue))
+			MinSensivityFactor, MaxSensivityFactor))
 	}
 
 	high := (factor & 0xE0) >> 5
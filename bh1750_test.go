@@ -0,0 +1,96 @@
+package bh1750
+
+import "testing"
+
+func TestNextAutoRangeStep(t *testing.T) {
+	const defaultFactor = 69
+	const satThreshold = 0xFFFF * 9 / 10
+	const lowThreshold = 1000
+
+	cases := []struct {
+		name           string
+		raw            uint16
+		resolution     ResolutionMode
+		factor         byte
+		wantResolution ResolutionMode
+		wantFactor     byte
+		wantChanged    bool
+	}{
+		{
+			name:           "probe in normal range stays put",
+			raw:            20000,
+			resolution:     HighResolution,
+			factor:         defaultFactor,
+			wantResolution: HighResolution,
+			wantFactor:     defaultFactor,
+			wantChanged:    false,
+		},
+		{
+			name:           "saturated probe falls back to LowResolution",
+			raw:            satThreshold,
+			resolution:     HighResolution,
+			factor:         defaultFactor,
+			wantResolution: LowResolution,
+			wantFactor:     defaultFactor,
+			wantChanged:    true,
+		},
+		{
+			name:           "dim probe jumps to HighestResolution and max factor",
+			raw:            lowThreshold - 1,
+			resolution:     HighResolution,
+			factor:         defaultFactor,
+			wantResolution: HighestResolution,
+			wantFactor:     MaxSensivityFactor,
+			wantChanged:    true,
+		},
+		{
+			name:           "still saturated after fallback floors the factor",
+			raw:            satThreshold,
+			resolution:     LowResolution,
+			factor:         defaultFactor,
+			wantResolution: LowResolution,
+			wantFactor:     MinSensivityFactor,
+			wantChanged:    true,
+		},
+		{
+			name:           "no longer saturated after fallback stops",
+			raw:            20000,
+			resolution:     LowResolution,
+			factor:         defaultFactor,
+			wantResolution: LowResolution,
+			wantFactor:     defaultFactor,
+			wantChanged:    false,
+		},
+		{
+			name:           "factor already floored stops regardless of raw",
+			raw:            satThreshold,
+			resolution:     LowResolution,
+			factor:         MinSensivityFactor,
+			wantResolution: LowResolution,
+			wantFactor:     MinSensivityFactor,
+			wantChanged:    false,
+		},
+		{
+			name:           "already boosted to HighestResolution stops regardless of raw",
+			raw:            lowThreshold - 1,
+			resolution:     HighestResolution,
+			factor:         MaxSensivityFactor,
+			wantResolution: HighestResolution,
+			wantFactor:     MaxSensivityFactor,
+			wantChanged:    false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gotResolution, gotFactor, gotChanged := nextAutoRangeStep(
+				c.raw, c.resolution, c.factor, defaultFactor, satThreshold, lowThreshold)
+
+			if gotResolution != c.wantResolution || gotFactor != c.wantFactor || gotChanged != c.wantChanged {
+				t.Errorf("nextAutoRangeStep(%d, %v, %d) = (%v, %d, %v), want (%v, %d, %v)",
+					c.raw, c.resolution, c.factor, gotResolution, gotFactor, gotChanged,
+					c.wantResolution, c.wantFactor, c.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package bh1750
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	i2c "github.com/d2r2/go-i2c"
+)
+
+// Sample carries a single ambient light reading produced by Monitor.
+type Sample struct {
+	Lux        float64
+	Timestamp  time.Time
+	Resolution ResolutionMode
+	Factor     byte
+}
+
+// Monitor runs a background goroutine that continuously polls a
+// BH1750 sensor and publishes Sample values, replacing the manual
+// continuous measurement loop found in examples. Monitor does not
+// watch OS signals itself: cancel the context passed to Start, or
+// call Stop, to end polling. Only Stop powers the sensor down; if the
+// context is canceled first, the goroutine exits with the sensor
+// still measuring and a later Stop is a no-op.
+type Monitor struct {
+	sensor     *BH1750
+	i2cBus     *i2c.I2C
+	resolution ResolutionMode
+
+	mu        sync.RWMutex
+	pollDelay time.Duration
+	latest    Sample
+	hasLatest bool
+	running   bool
+
+	readings chan Sample
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewMonitor return new Monitor bound to i2cBus, measuring at resolution.
+// Call Start to begin polling; a Monitor can be Start/Stop-ed and
+// restarted any number of times, one cycle at a time.
+func NewMonitor(i2cBus *i2c.I2C, resolution ResolutionMode) *Monitor {
+	return &Monitor{
+		sensor:     NewBH1750(),
+		i2cBus:     i2cBus,
+		resolution: resolution,
+	}
+}
+
+// SetPollDelay override the delay between samples. Zero (the default)
+// means use the resolution's own recommended wait interval.
+func (m *Monitor) SetPollDelay(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pollDelay = d
+}
+
+// Readings return the channel samples are published on. A new channel
+// is created on every Start and closed once Stop has fully shut the
+// background goroutine down, so call Readings again after restarting
+// a stopped Monitor.
+func (m *Monitor) Readings() <-chan Sample {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.readings
+}
+
+// Latest return the most recently published sample, non-blocking.
+// The second return value is false if no sample has been taken yet.
+func (m *Monitor) Latest() (Sample, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.latest, m.hasLatest
+}
+
+// Start begins continuous measurement and launches the background
+// polling goroutine. The goroutine exits once ctx is canceled or
+// Stop is called. Start returns an error if the Monitor is already
+// running; call Stop first to restart it.
+func (m *Monitor) Start(ctx context.Context) error {
+	lg.Debug("Start monitor...")
+
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return errors.New("monitor is already running")
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	wait, err := m.sensor.StartMeasureAmbientLightContinuously(m.i2cBus, m.resolution)
+	if err != nil {
+		m.mu.Lock()
+		m.running = false
+		m.mu.Unlock()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	m.readings = make(chan Sample)
+
+	go m.run(ctx, wait)
+
+	return nil
+}
+
+// run polls the sensor on the recommended (or overridden) cadence
+// and publishes samples until ctx is done.
+func (m *Monitor) run(ctx context.Context, wait time.Duration) {
+	// Defers run LIFO: readings is closed first, then running is
+	// cleared, then done is closed last — so by the time Stop's
+	// <-m.done unblocks, m.running is already false and a caller is
+	// free to Start the Monitor again.
+	defer close(m.done)
+	defer func() {
+		m.mu.Lock()
+		m.running = false
+		m.mu.Unlock()
+	}()
+	defer close(m.readings)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			lux, err := m.sensor.FetchMeasuredAmbientLightFloat(m.i2cBus)
+			if err != nil {
+				lg.Error(err)
+			} else {
+				sample := Sample{
+					Lux:        lux,
+					Timestamp:  time.Now(),
+					Resolution: m.resolution,
+					Factor:     m.sensor.factor,
+				}
+
+				m.mu.Lock()
+				m.latest = sample
+				m.hasLatest = true
+				m.mu.Unlock()
+
+				select {
+				case m.readings <- sample:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			m.mu.RLock()
+			delay := m.pollDelay
+			m.mu.RUnlock()
+			if delay == 0 {
+				delay = wait
+			}
+			timer.Reset(delay)
+		}
+	}
+}
+
+// Stop cancels the background goroutine, waits for it to finish and
+// powers the sensor down. The readings channel is closed once this
+// returns. Stop is a no-op if the Monitor isn't running.
+func (m *Monitor) Stop() error {
+	lg.Debug("Stop monitor...")
+
+	m.mu.RLock()
+	running := m.running
+	m.mu.RUnlock()
+	if !running {
+		return nil
+	}
+
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.done != nil {
+		<-m.done
+	}
+
+	return m.sensor.PowerDown(m.i2cBus)
+}